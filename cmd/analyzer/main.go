@@ -1,22 +1,36 @@
 package main
 
 import (
+	"911/internal/analytics"
 	"911/internal/model"
 	"911/internal/service"
+	"911/internal/store"
+	_ "911/internal/store/csvstore"
+	_ "911/internal/store/sqlitestore"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"text/tabwriter" // 使用标准库，无需外部依赖
+	"time"
 )
 
+// riskFreeRate 年化无风险利率，用于 Sharpe/Sortino 的基准，暂时写死为 0
+const riskFreeRate = 0.0
+
 func main() {
 	// 1. 定义命令行参数
-	ledgerPath := flag.String("in", "data/ledger.csv", "Path to the ledger CSV file")
+	ledgerPath := flag.String("in", "data/ledger.csv", "Path to ledger file (CSV path or SQLite DB path, depending on --store)")
+	storeBackend := flag.String("store", "csv", "Ledger backend: \"csv\" or \"sqlite\"")
 	flag.Parse()
 
 	// 2. 加载数据
-	transactions, err := service.LoadTransactions(*ledgerPath)
+	ledger, err := store.New(store.Config{Backend: *storeBackend, Path: *ledgerPath})
+	if err != nil {
+		log.Fatalf("❌ 错误: 无法打开账本存储: %v", err)
+	}
+	transactions, err := ledger.All()
 	if err != nil {
 		log.Fatalf("❌ 错误: 无法加载账本文件: %v", err)
 	}
@@ -26,6 +40,11 @@ func main() {
 
 	// 4. 输出仪表盘
 	printDashboard(status)
+	printInstrumentTable(status)
+
+	// 5. 风险指标 (Sharpe/Sortino/最大回撤/CAGR)
+	riskReport := analytics.ComputeRiskReport(transactions, riskFreeRate, time.Now())
+	printRiskReport(riskReport)
 }
 
 func printDashboard(s model.PortfolioStatus) {
@@ -73,3 +92,58 @@ func printDashboard(s model.PortfolioStatus) {
 	fmt.Println("========================================")
 	fmt.Println("")
 }
+
+// printInstrumentTable 打印按合约分组的明细，看清楚究竟是哪个币在喂养 911
+func printInstrumentTable(s model.PortfolioStatus) {
+	if len(s.ByInstrument) == 0 {
+		return
+	}
+
+	fmt.Println("----------------------------------------")
+	fmt.Println("   📊  BY INSTRUMENT")
+	fmt.Println("----------------------------------------")
+
+	// 按 InstId 排序，保证每次输出顺序稳定
+	instIds := make([]string, 0, len(s.ByInstrument))
+	for instId := range s.ByInstrument {
+		instIds = append(instIds, instId)
+	}
+	sort.Strings(instIds)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "INSTRUMENT\tTRADES\tWIN RATE\tAVG WIN\tAVG LOSS\tPROFIT FACTOR\tLONG EXP.\tSHORT EXP.")
+	fmt.Fprintln(w, "----------\t------\t--------\t-------\t--------\t-------------\t---------\t----------")
+
+	for _, instId := range instIds {
+		stat := s.ByInstrument[instId]
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t$%.2f\t$%.2f\t%.2f\t$%.2f\t$%.2f\n",
+			stat.InstId, stat.TotalTrades(), stat.WinRate(), stat.AvgWin(), stat.AvgLoss(),
+			stat.ProfitFactor(), stat.LongExpectancy(), stat.ShortExpectancy())
+	}
+
+	w.Flush()
+	fmt.Println("----------------------------------------")
+	fmt.Println("")
+}
+
+// printRiskReport 打印风险调整后的进度，而不只是原始余额
+func printRiskReport(r analytics.RiskReport) {
+	fmt.Println("----------------------------------------")
+	fmt.Println("   ⚠️  RISK METRICS")
+	fmt.Println("----------------------------------------")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tVALUE\tNOTE")
+	fmt.Fprintln(w, "------\t-----\t----")
+
+	fmt.Fprintf(w, "Max Drawdown\t-$%.2f (-%.2f%%)\tLongest: %d days\n",
+		r.MaxDrawdownValue, r.MaxDrawdownPct, r.LongestDrawdownDays)
+	fmt.Fprintf(w, "Annualized Volatility\t%.2f%%\tDaily resampled, sqrt(365)\n", r.AnnualizedVolatility*100)
+	fmt.Fprintf(w, "Sharpe Ratio\t%.2f\trf=0 unless configured\n", r.Sharpe)
+	fmt.Fprintf(w, "Sortino Ratio\t%.2f\tDownside deviation only\n", r.Sortino)
+	fmt.Fprintf(w, "CAGR\t%.2f%%\tOver %.0f days\n", r.CAGR*100, r.Duration.Hours()/24)
+
+	w.Flush()
+	fmt.Println("----------------------------------------")
+	fmt.Println("")
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"911/internal/model"
+	"911/internal/store"
+	_ "911/internal/store/csvstore"
+	_ "911/internal/store/sqlitestore"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+// header 是 XLSX 回填文件的列顺序，和 model.Transaction 的字段顺序保持一致，
+// ord_id 是唯一的可选列 (留空即可，早年 OTC/提现记录通常没有订单号)
+var header = []string{"timestamp", "type", "amount", "asset", "inst_id", "ord_id", "side", "note"}
+
+// transactionTypes 是 --template 生成的下拉框候选值，和 model.TransactionType 保持一致
+var transactionTypes = []string{string(model.TypeDeposit), string(model.TypeWithdrawal), string(model.TypePnL)}
+
+func main() {
+	inPath := flag.String("in", "", "Path to the .xlsx file to backfill")
+	ledgerPath := flag.String("out", "data/ledger.csv", "Path to ledger file (CSV path or SQLite DB path, depending on --store)")
+	storeBackend := flag.String("store", "csv", "Ledger backend: \"csv\" or \"sqlite\"")
+	templatePath := flag.String("template", "", "If set, write a blank XLSX template to this path and exit (ignores --in)")
+	flag.Parse()
+
+	if *templatePath != "" {
+		if err := writeTemplate(*templatePath); err != nil {
+			log.Fatalf("生成模板失败: %v", err)
+		}
+		fmt.Printf("📄 模板已生成: %s\n", *templatePath)
+		return
+	}
+
+	if *inPath == "" {
+		log.Fatalf("请通过 --in 指定要回填的 .xlsx 文件 (或用 --template 先生成一份模板)")
+	}
+
+	// 1. 读取并校验手工补录的交易
+	txs, err := readXLSX(*inPath)
+	if err != nil {
+		log.Fatalf("解析 XLSX 失败: %v", err)
+	}
+	fmt.Printf("📖 从 [%s] 读取到 %d 条待回填记录\n", *inPath, len(txs))
+
+	// 2. 复用和 cmd/importer 一致的去重管线，接入同一个账本
+	ledger, err := store.New(store.Config{Backend: *storeBackend, Path: *ledgerPath})
+	if err != nil {
+		log.Fatalf("无法打开账本存储: %v", err)
+	}
+	existing, err := ledger.All()
+	if err != nil {
+		log.Fatalf("读取账本失败: %v", err)
+	}
+	newTxs := store.Dedupe(existing, txs)
+
+	if len(newTxs) == 0 {
+		fmt.Println("✨ 没有发现新记录 (都已存在于账本中).")
+		return
+	}
+
+	added, err := ledger.Append(newTxs)
+	if err != nil {
+		log.Fatalf("写入账本失败: %v", err)
+	}
+	fmt.Printf("📥 回填成功，新增 %d 条记录！\n", added)
+}
+
+// readXLSX 读取第一个 sheet，跳过表头，逐行校验并转换为 model.Transaction
+func readXLSX(path string) ([]model.Transaction, error) {
+	file, err := xlsx.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件 [%s]: %v", path, err)
+	}
+	if len(file.Sheets) == 0 {
+		return nil, fmt.Errorf("文件中没有任何 sheet")
+	}
+
+	var transactions []model.Transaction
+	for i, row := range file.Sheets[0].Rows {
+		if i == 0 {
+			continue // Skip Header
+		}
+		cells := row.Cells
+		if len(cells) == 0 || cells[0].String() == "" {
+			continue // 跳过空行
+		}
+
+		tx, err := parseRow(cells, i+1)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
+// parseRow 校验并转换一行，列顺序见 header
+func parseRow(cells []*xlsx.Cell, lineNo int) (model.Transaction, error) {
+	get := func(idx int) string {
+		if idx >= len(cells) {
+			return ""
+		}
+		return cells[idx].String()
+	}
+
+	ts, err := time.Parse(time.RFC3339, get(0))
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("第 %d 行时间格式错误 (需用 RFC3339): %v", lineNo, err)
+	}
+
+	txType := model.TransactionType(get(1))
+	if !isValidType(txType) {
+		return model.Transaction{}, fmt.Errorf("第 %d 行交易类型非法: %q (应为 DEPOSIT/WITHDRAWAL/PNL)", lineNo, txType)
+	}
+
+	amount, err := strconv.ParseFloat(get(2), 64)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("第 %d 行金额错误: %v", lineNo, err)
+	}
+
+	side := model.PositionSide(get(6))
+	if side == "" {
+		side = model.SideFlat
+	}
+
+	return model.Transaction{
+		Timestamp: ts,
+		Type:      txType,
+		Amount:    amount,
+		Asset:     get(3),
+		InstId:    get(4),
+		OrdId:     get(5),
+		Side:      side,
+		Note:      get(7),
+	}, nil
+}
+
+func isValidType(t model.TransactionType) bool {
+	switch t {
+	case model.TypeDeposit, model.TypeWithdrawal, model.TypePnL:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeTemplate 生成一份只有表头的空白 XLSX，并给 type 列加上下拉校验，
+// 减少手工补录时把 TransactionType 拼错的可能性
+func writeTemplate(path string) error {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("ledger")
+	if err != nil {
+		return err
+	}
+
+	headerRow := sheet.AddRow()
+	for _, col := range header {
+		headerRow.AddCell().SetString(col)
+	}
+
+	// type 列 (索引 1) 限定为合法的 TransactionType 取值，下拉框覆盖后续 1000 行
+	const templateRows = 1000
+	dv := xlsx.NewDataValidation(1, 1, templateRows, 1, true)
+	if err := dv.SetDropList(transactionTypes); err != nil {
+		return err
+	}
+	sheet.AddDataValidation(dv)
+
+	return file.Save(path)
+}
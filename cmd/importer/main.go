@@ -1,24 +1,48 @@
 package main
 
 import (
+	"911/internal/exchange"
+	_ "911/internal/exchange/binance"
+	_ "911/internal/exchange/okx"
 	"911/internal/model"
-	"911/internal/okx"
+	"911/internal/notifier"
+	_ "911/internal/notifier/lark"
+	_ "911/internal/notifier/telegram"
 	"911/internal/service"
-	"encoding/csv"
+	"911/internal/store"
+	_ "911/internal/store/csvstore"
+	_ "911/internal/store/sqlitestore"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"sort"
 	"strconv"
-	"strings" // 🟢 聚合逻辑需要用到 strings 包，请确保保留
+	"strings"
 	"time"
 )
 
+// importConfig 对应 config.json 的整体结构：多个交易所配置合并导入 + 通知渠道
+type importConfig struct {
+	Exchanges []exchange.Config `json:"exchanges"`
+	Notifiers []notifier.Config `json:"notifiers"`
+}
+
+// notifyRetryAttempts 每个通知渠道发送失败后的最大重试次数
+const notifyRetryAttempts = 3
+
+// drawdownAlertDefaultPct 触发回撤告警的默认阈值 (相对历史最高点)
+const drawdownAlertDefaultPct = 15.0
+
 func main() {
 	configFile := flag.String("config", "config.json", "Path to config file")
-	ledgerPath := flag.String("out", "data/ledger.csv", "Path to ledger csv")
+	ledgerPath := flag.String("out", "data/ledger.csv", "Path to ledger file (CSV path or SQLite DB path, depending on --store)")
+	storeBackend := flag.String("store", "csv", "Ledger backend: \"csv\" or \"sqlite\"")
+	dryRun := flag.Bool("dry-run", false, "Only print notifications instead of actually sending them")
+	drawdownAlertPct := flag.Float64("drawdown-alert-pct", drawdownAlertDefaultPct, "Drawdown from all-time-high (%) that triggers a DrawdownAlertEvent")
 	flag.Parse()
 
 	// 1. 加载配置
@@ -26,78 +50,120 @@ func main() {
 	if err != nil {
 		log.Fatalf("无法读取配置文件: %v", err)
 	}
-	var cfg okx.Config
-	json.Unmarshal(cfgData, &cfg)
+	var cfg importConfig
+	if err := json.Unmarshal(cfgData, &cfg); err != nil {
+		log.Fatalf("配置文件解析失败: %v", err)
+	}
+	if len(cfg.Exchanges) == 0 {
+		log.Fatalf("config.json 中未配置任何交易所 (exchanges 字段为空)")
+	}
 
-	// 2. 获取本地最新时间戳 (用于去重)
-	lastTimestamp := getLastRecordTimestamp(*ledgerPath)
+	// 2. 打开账本存储，加载已有记录 (用于去重、以及导入前后的状态对比)
+	ledger, err := store.New(store.Config{Backend: *storeBackend, Path: *ledgerPath})
+	if err != nil {
+		log.Fatalf("无法打开账本存储: %v", err)
+	}
+	existingTransactions, err := ledger.All()
+	if err != nil {
+		log.Fatalf("读取账本失败: %v", err)
+	}
+	lastTimestamp, err := ledger.LastTimestamp()
+	if err != nil {
+		log.Fatalf("读取账本最新时间戳失败: %v", err)
+	}
 	if !lastTimestamp.IsZero() {
 		fmt.Printf("📅 本地最新记录时间: %s\n", lastTimestamp.Format("2006-01-02 15:04:05"))
 	}
+	statusBefore := service.AnalyzePortfolio(existingTransactions)
 
-	// 3. API 拉取 (归档模式)
-	client := okx.NewClient(cfg)
-	rawBills, err := client.FetchBills()
-	if err != nil {
-		log.Fatalf("获取数据失败: %v", err)
+	// 3. 逐个交易所拉取，再合并流水 (归档模式)
+	ctx := context.Background()
+	var allRawBills []exchange.RawBill
+	for _, exCfg := range cfg.Exchanges {
+		ex, err := exchange.New(exCfg)
+		if err != nil {
+			log.Fatalf("构造交易所实例失败 [%s]: %v", exCfg.Type, err)
+		}
+
+		fmt.Printf("🔌 正在拉取交易所 [%s] 的数据...\n", ex.Name())
+		bills, err := ex.FetchBills(ctx, lastTimestamp)
+		if err != nil {
+			log.Fatalf("获取数据失败 [%s]: %v", ex.Name(), err)
+		}
+		fmt.Printf("✅ [%s] 返回原始流水: %d 条\n", ex.Name(), len(bills))
+		allRawBills = append(allRawBills, bills...)
 	}
-	fmt.Printf("✅ API 返回原始流水: %d 条\n", len(rawBills))
 
-	// 4. 🟢 核心：数据聚合 (Merge Bills by OrderID)
-	// 这里会调用下方的 aggregateAndMapBills 函数进行合并
-	transactions := aggregateAndMapBills(rawBills)
-	
+	// 4. 核心：数据聚合 (Merge Bills by OrderID，跨所合并)
+	transactions := aggregateAndMapBills(allRawBills)
+
 	// 打印聚合效果
-	fmt.Printf("🔄 聚合后交易记录: %d 条 (合并了 %d 条零碎流水)\n", 
-		len(transactions), len(rawBills)-len(transactions))
+	fmt.Printf("🔄 聚合后交易记录: %d 条 (合并了 %d 条零碎流水)\n",
+		len(transactions), len(allRawBills)-len(transactions))
 
-	// 5. 过滤与去重
-	var newTransactions []model.Transaction
+	// 5. 过滤零金额交易，再交给 store 按 (timestamp, ord_id, amount) 去重，
+	// 不再依赖"时间戳严格递增"这条脆弱规则，修复了同一时间戳多笔成交被误删的 bug
+	var candidates []model.Transaction
 	for _, trans := range transactions {
-		// 过滤 0 金额交易
 		if trans.Amount == 0 {
 			continue
 		}
-		// 🟢 核心：时间去重 (只写入比 CSV 中更新的数据)
-		if !trans.Timestamp.After(lastTimestamp) {
-			continue
-		}
-		newTransactions = append(newTransactions, trans)
+		candidates = append(candidates, trans)
 	}
+	newTransactions := store.Dedupe(existingTransactions, candidates)
 
 	// 6. 写入
 	if len(newTransactions) > 0 {
-		appendNewRecords(*ledgerPath, newTransactions)
+		added, err := ledger.Append(newTransactions)
+		if err != nil {
+			log.Fatalf("写入账本失败: %v", err)
+		}
+		fmt.Printf("📥 成功导入 %d 条新记录！\n", added)
 	} else {
 		fmt.Println("✨ 没有发现比本地账本更新的记录 (All up to date).")
 	}
+
+	// 7. 对比导入前后的状态，触发里程碑/回撤/出金/日报通知
+	allTransactions := append(append([]model.Transaction{}, existingTransactions...), newTransactions...)
+	statusAfter := service.AnalyzePortfolio(allTransactions)
+	events := detectEvents(statusBefore, statusAfter, allTransactions, newTransactions, *drawdownAlertPct)
+
+	if len(events) == 0 {
+		return
+	}
+
+	notifiers := buildNotifiers(cfg.Notifiers)
+	dispatchEvents(context.Background(), notifiers, events, *dryRun)
 }
 
-// 🟢 核心函数：将分散的流水聚合为逻辑交易
-func aggregateAndMapBills(bills []okx.Bill) []model.Transaction {
-	// Key 是 OrdId (订单号), Value 是聚合后的 Transaction 指针
+// aggregateAndMapBills 把分散的流水聚合为逻辑交易
+func aggregateAndMapBills(bills []exchange.RawBill) []model.Transaction {
+	// Key 是 Exchange+OrdId (订单号)，用交易所前缀隔离避免跨所订单号碰撞
 	mergedMap := make(map[string]*model.Transaction)
-	
-	var resultList []model.Transaction // 最终结果
+	// 记录每个订单见到的 (exchange, subType)，聚合结束后才能推断方向
+	orderMeta := make(map[string]orderInfo)
+
+	var resultList []model.Transaction     // 最终结果
 	var standaloneList []model.Transaction // 无法聚合的（如资金费）
 
 	for _, bill := range bills {
 		amount, _ := strconv.ParseFloat(bill.BalChg, 64)
 		tsInt, _ := strconv.ParseInt(bill.Ts, 10, 64)
 		ts := time.UnixMilli(tsInt)
-		
+
 		// 1. 优先判断是否属于“交易聚合”范畴
-		// 只要有 OrdId，无论 OKX 标记它是什么类型（Fee, Withdrawal, etc.），都视为交易的一部分
+		// 只要有 OrdId，无论交易所把它标记成什么类型（Fee, Withdrawal, etc.），都视为交易的一部分
 		if bill.OrdId != "" {
-			if existing, found := mergedMap[bill.OrdId]; found {
+			key := bill.Exchange + ":" + bill.OrdId
+			if existing, found := mergedMap[key]; found {
 				// A. 已存在：合并金额
-				existing.Amount += amount 
-				
+				existing.Amount += amount
+
 				// 时间取最新的
 				if ts.After(existing.Timestamp) {
 					existing.Timestamp = ts
 				}
-				
+
 				// 备注合并 (避免重复)
 				if !strings.Contains(existing.Note, bill.InstId) {
 					existing.Note += " " + bill.InstId
@@ -107,20 +173,26 @@ func aggregateAndMapBills(bills []okx.Bill) []model.Transaction {
 				// 强制类型为 PNL，因为这是交易产生的变动
 				t := &model.Transaction{
 					Timestamp: ts,
-					Type:      model.TypePnL, 
+					Type:      model.TypePnL,
 					Amount:    amount,
 					Asset:     bill.Ccy,
-					Note:      fmt.Sprintf("Trade (%s)", bill.InstId),
+					InstId:    bill.InstId,
+					OrdId:     bill.OrdId,
+					Note:      fmt.Sprintf("[%s] Trade (%s)", bill.Exchange, bill.InstId),
 				}
-				mergedMap[bill.OrdId] = t
+				mergedMap[key] = t
+			}
+			// 记录这条腿的 subType，留到合并完成后统一推断方向
+			if meta, found := orderMeta[key]; !found || meta.subType == "" {
+				orderMeta[key] = orderInfo{exchange: bill.Exchange, subType: bill.SubType}
 			}
 		} else {
 			// 2. 没有 OrdId 的，归为孤立事件 (Standalone)
 			// 如：资金费 (Funding Fee)、真正的出入金、划转
 			transType := determineType(bill.Type)
-			
-			// 如果是资金费(Type 8)，我们在 Note 里标明
-			note := getNoteFromType(bill.Type)
+
+			// 如果是资金费，我们在 Note 里标明
+			note := fmt.Sprintf("[%s] %s", bill.Exchange, getNoteFromType(bill.Type))
 			if bill.InstId != "" {
 				note = fmt.Sprintf("%s (%s)", note, bill.InstId)
 			}
@@ -136,81 +208,185 @@ func aggregateAndMapBills(bills []okx.Bill) []model.Transaction {
 		}
 	}
 
-	// 将 Map 中的聚合结果转回 List
-	for _, t := range mergedMap {
+	// 将 Map 中的聚合结果转回 List，并推断每笔交易的持仓方向
+	for key, t := range mergedMap {
+		meta := orderMeta[key]
+		t.Side = inferSide(meta.exchange, meta.subType)
 		resultList = append(resultList, *t)
 	}
-	
+
 	// 加上孤立记录
 	resultList = append(resultList, standaloneList...)
-	
+
 	return resultList
 }
 
+// orderInfo 保存聚合过程中见到的订单元信息，用于合并完成后推断持仓方向
+type orderInfo struct {
+	exchange string
+	subType  string
+}
+
+// inferSide 推断一笔聚合后交易的持仓方向，只在有真实方向数据来源时才判断，
+// 否则返回 SideFlat —— 按盈亏正负号猜测方向等于把胜负关系伪装成多空关系，
+// 会让 InstrumentStats 的 Long/Short 统计变成对 WinCount/LossCount 的重新贴标签
+func inferSide(exchangeName, subType string) model.PositionSide {
+	if exchangeName == "okx" {
+		// OKX 的 subType 开平仓代码: 3=开多 4=开空 5=平多 6=平空
+		switch subType {
+		case "3", "5":
+			return model.SideLong
+		case "4", "6":
+			return model.SideShort
+		}
+	}
+
+	return model.SideFlat
+}
+
+// determineType 把交易所无关的归一化类型映射为账本的 Transaction 类型
 func determineType(billType string) model.TransactionType {
 	switch billType {
-	case "1": return model.TypeDeposit
-	case "2": return model.TypeWithdrawal
-	default:  return model.TypePnL
+	case exchange.BillTypeDeposit:
+		return model.TypeDeposit
+	case exchange.BillTypeWithdrawal:
+		return model.TypeWithdrawal
+	default:
+		return model.TypePnL
 	}
 }
 
 func getNoteFromType(billType string) string {
 	switch billType {
-	case "1": return "Deposit"
-	case "2": return "Withdrawal"
-	case "8": return "Funding Fee"
-	default:  return "Auto Import"
+	case exchange.BillTypeDeposit:
+		return "Deposit"
+	case exchange.BillTypeWithdrawal:
+		return "Withdrawal"
+	case exchange.BillTypeFundingFee:
+		return "Funding Fee"
+	default:
+		return "Auto Import"
 	}
 }
 
-func getLastRecordTimestamp(filePath string) time.Time {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return time.Time{}
-	}
-	txs, err := service.LoadTransactions(filePath)
-	if err != nil || len(txs) == 0 {
-		return time.Time{}
+// allTimeHigh 扫描流水按时间顺序累加余额，返回历史最高点
+func allTimeHigh(txs []model.Transaction) float64 {
+	sorted := make([]model.Transaction, len(txs))
+	copy(sorted, txs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var balance, peak float64
+	for _, tx := range sorted {
+		balance += tx.Amount
+		if balance > peak {
+			peak = balance
+		}
 	}
-	return txs[len(txs)-1].Timestamp
+	return peak
 }
 
-func appendNewRecords(filePath string, newTxs []model.Transaction) {
-	fileMode := os.O_APPEND | os.O_WRONLY
-	needHeader := false
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		fileMode = os.O_CREATE | os.O_WRONLY
-		needHeader = true
-	}
+// detectEvents 对比导入前后的账户状态，生成需要推送的通知事件
+func detectEvents(before, after model.PortfolioStatus, allTxs, newTxs []model.Transaction, drawdownAlertPct float64) []notifier.Event {
+	var events []notifier.Event
+	now := time.Now()
 
-	f, err := os.OpenFile(filePath, fileMode, 0644)
-	if err != nil {
-		log.Fatalf("无法打开文件: %v", err)
+	// 1. ProgressCrossedEvent：每跨过一个 10% 的里程碑
+	milestoneBefore := int(before.Progress() / 10)
+	milestoneAfter := int(after.Progress() / 10)
+	for m := milestoneBefore + 1; m <= milestoneAfter; m++ {
+		events = append(events, notifier.Event{
+			Type:      notifier.EventProgressCrossed,
+			Title:     fmt.Sprintf("🎉 进度突破 %d%%", m*10),
+			Message:   fmt.Sprintf("当前余额 $%.2f，距 911 (%.0f) 已完成 %.2f%%", after.CurrentBalance, after.Target, after.Progress()),
+			Timestamp: now,
+		})
 	}
-	defer f.Close()
 
-	w := csv.NewWriter(f)
-	if needHeader {
-		w.Write([]string{"timestamp", "type", "amount", "asset", "note"})
+	// 2. NewHarvestEvent：每一笔新的出金
+	for _, tx := range newTxs {
+		if tx.Type != model.TypeWithdrawal {
+			continue
+		}
+		events = append(events, notifier.Event{
+			Type:      notifier.EventNewHarvest,
+			Title:     "🏖️ 新的一笔出金",
+			Message:   fmt.Sprintf("提取 $%.2f，累计已提取 $%.2f", math.Abs(tx.Amount), after.TotalHarvested),
+			Timestamp: tx.Timestamp,
+		})
 	}
 
-	// 排序：时间正序写入
-	sort.Slice(newTxs, func(i, j int) bool {
-		return newTxs[i].Timestamp.Before(newTxs[j].Timestamp)
-	})
+	// 3. DrawdownAlertEvent：余额相对历史最高点回撤超过阈值
+	ath := allTimeHigh(allTxs)
+	if ath > 0 {
+		drawdownPct := (ath - after.CurrentBalance) / ath * 100
+		if drawdownPct >= drawdownAlertPct {
+			events = append(events, notifier.Event{
+				Type:      notifier.EventDrawdownAlert,
+				Title:     "⚠️ 触发回撤告警",
+				Message:   fmt.Sprintf("当前余额 $%.2f，较历史最高点 $%.2f 回撤 %.2f%%", after.CurrentBalance, ath, drawdownPct),
+				Timestamp: now,
+			})
+		}
+	}
 
-	count := 0
+	// 4. DailyPnLDigestEvent：汇总本次新导入中属于今天的交易盈亏
+	var dailyPnL float64
+	var dailyTrades int
 	for _, tx := range newTxs {
-		record := []string{
-			tx.Timestamp.Format(time.RFC3339),
-			string(tx.Type),
-			fmt.Sprintf("%.8f", tx.Amount),
-			tx.Asset,
-			tx.Note,
+		if tx.Type != model.TypePnL {
+			continue
+		}
+		if !isSameDay(tx.Timestamp, now) {
+			continue
 		}
-		w.Write(record)
-		count++
+		dailyPnL += tx.Amount
+		dailyTrades++
 	}
-	w.Flush()
-	fmt.Printf("📥 成功导入 %d 条新记录！\n", count)
-}
\ No newline at end of file
+	if dailyTrades > 0 {
+		events = append(events, notifier.Event{
+			Type:      notifier.EventDailyPnLDigest,
+			Title:     "📒 今日盈亏摘要",
+			Message:   fmt.Sprintf("今日成交 %d 笔，净盈亏 $%.2f", dailyTrades, dailyPnL),
+			Timestamp: now,
+		})
+	}
+
+	return events
+}
+
+func isSameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// buildNotifiers 按配置构造所有通知渠道实例，单个渠道构造失败只打印警告不中断导入
+func buildNotifiers(cfgs []notifier.Config) []notifier.Notifier {
+	var notifiers []notifier.Notifier
+	for _, c := range cfgs {
+		n, err := notifier.New(c)
+		if err != nil {
+			fmt.Printf("⚠️ 跳过通知渠道 [%s]: %v\n", c.Type, err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
+
+// dispatchEvents 把事件推送给所有通知渠道；dryRun 模式下只打印不真正发送
+func dispatchEvents(ctx context.Context, notifiers []notifier.Notifier, events []notifier.Event, dryRun bool) {
+	for _, event := range events {
+		if dryRun {
+			fmt.Printf("🔔 [dry-run] %s: %s\n", event.Title, event.Message)
+			continue
+		}
+		for _, n := range notifiers {
+			if err := notifier.SendWithRetry(ctx, n, event, notifyRetryAttempts); err != nil {
+				fmt.Printf("❌ 通知发送失败 [%s]: %v\n", n.Name(), err)
+			}
+		}
+	}
+}
@@ -1,87 +1,275 @@
-package main
-
-import (
-	"911/internal/service"
-	"flag"
-	"fmt"
-	"image/color"
-	"log"
-
-	"gonum.org/v1/plot"
-	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/vg"
-)
-
-func main() {
-	// 1. 定义参数
-	inPath := flag.String("in", "data/ledger.csv", "Input CSV file path")
-	outPath := flag.String("out", "assets/equity_curve.png", "Output PNG file path")
-	flag.Parse()
-
-	// 2. 加载数据
-	txs, err := service.LoadTransactions(*inPath)
-	if err != nil {
-		log.Fatalf("无法加载数据: %v", err)
-	}
-
-	if len(txs) == 0 {
-		log.Fatalf("数据为空，无法绘图")
-	}
-
-	// 3. 准备绘图数据 (XY轴坐标点)
-	pts := make(plotter.XYs, len(txs))
-
-	var currentBalance float64 = 0
-
-	// 注意：这里我们假设数据已经是按时间排序的 (CSV追加模式通常如此)
-	// 如果不是，在 Service 层需要加一个 Sort
-	for i, tx := range txs {
-		// 累加金额，计算实时余额
-		currentBalance += tx.Amount
-
-		// X轴: 交易序列号 (第几笔交易) - 简单直观
-		// (如果想用时间作X轴会复杂很多，V1版本建议用交易次数，更能反映交易频率)
-		pts[i].X = float64(i + 1)
-		pts[i].Y = currentBalance
-	}
-
-	// 4. 配置图表
-	p := plot.New()
-
-	p.Title.Text = "Project 911: Equity Curve"
-	p.X.Label.Text = "Trade Count"
-	p.Y.Label.Text = "Equity (USD)"
-
-	// 添加网格线
-	p.Add(plotter.NewGrid())
-
-	// 创建曲线
-	line, points, err := plotter.NewLinePoints(pts)
-	if err != nil {
-		log.Fatalf("创建曲线失败: %v", err)
-	}
-
-	// 样式调整
-	line.Color = color.RGBA{R: 0, G: 128, B: 255, A: 255} // 科技蓝
-	line.Width = vg.Points(2)                             // 线宽
-	points.Shape = nil                                    // 不显示具体的点，只显示线，保持整洁
-
-	// 添加目标线 (Porsche Price) - 可选
-	targetLine, _ := plotter.NewLine(plotter.XYs{
-		{X: 0, Y: 120000},
-		{X: float64(len(txs) + 5), Y: 120000},
-	})
-	targetLine.Color = color.RGBA{R: 255, G: 0, B: 0, A: 100} // 红色虚线效果
-	targetLine.LineStyle.Dashes = []vg.Length{vg.Points(5), vg.Points(5)}
-
-	// 将元素加入画布
-	p.Add(line, targetLine)
-
-	// 5. 保存图片
-	// 宽度 8 Inch, 高度 4 Inch
-	if err := p.Save(8*vg.Inch, 4*vg.Inch, *outPath); err != nil {
-		log.Fatalf("保存图片失败: %v", err)
-	}
-
-	fmt.Printf("✅ 绘图成功! 已保存至: %s\n", *outPath)
-}
+package main
+
+import (
+	"911/internal/model"
+	"911/internal/service"
+	"911/internal/store"
+	_ "911/internal/store/csvstore"
+	_ "911/internal/store/sqlitestore"
+	"flag"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"sort"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+func main() {
+	// 1. 定义参数
+	inPath := flag.String("in", "data/ledger.csv", "Input ledger path (CSV path or SQLite DB path, depending on --store)")
+	storeBackend := flag.String("store", "csv", "Ledger backend: \"csv\" or \"sqlite\"")
+	outPath := flag.String("out", "assets/equity_curve.png", "Output PNG file path")
+	xAxis := flag.String("x-axis", "count", "X axis mode: \"count\" (trade sequence) or \"time\" (calendar time)")
+	rolling := flag.Int("rolling", 0, "Overlay a rolling N-trade PnL bar chart as a third sub-plot (0 disables it)")
+	flag.Parse()
+
+	if *xAxis != "count" && *xAxis != "time" {
+		log.Fatalf("非法的 --x-axis 取值: %q (应为 count 或 time)", *xAxis)
+	}
+
+	// 2. 加载数据
+	ledger, err := store.New(store.Config{Backend: *storeBackend, Path: *inPath})
+	if err != nil {
+		log.Fatalf("无法打开账本存储: %v", err)
+	}
+	txs, err := ledger.All()
+	if err != nil {
+		log.Fatalf("无法加载数据: %v", err)
+	}
+
+	if len(txs) == 0 {
+		log.Fatalf("数据为空，无法绘图")
+	}
+
+	// 按时间排序，保证累加余额和 X 轴都是单调的
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Timestamp.Before(txs[j].Timestamp)
+	})
+
+	status := service.AnalyzePortfolio(txs)
+
+	// 3. 构建权益曲线 + 回撤曲线的数据点
+	equityPts, drawdownPts := buildCurves(txs, *xAxis)
+
+	// 4. 子图 1: 权益曲线 (叠加目标线 + 本金-目标的"风险/收益"色带)
+	equityPlot := newEquityPlot(equityPts, status, *xAxis)
+
+	// 5. 子图 2: 回撤曲线 (回撤区间填充为红色)
+	drawdownPlot := newDrawdownPlot(drawdownPts, *xAxis)
+
+	plots := [][]*plot.Plot{{equityPlot}, {drawdownPlot}}
+
+	// 6. 可选子图 3: 按 N 笔一组的滚动盈亏柱状图
+	if *rolling > 0 {
+		rollingPlot := newRollingPnLPlot(txs, *rolling)
+		plots = append(plots, []*plot.Plot{rollingPlot})
+	}
+
+	// 7. 按行堆叠所有子图并保存成一张 PNG
+	if err := saveStacked(plots, 8*vg.Inch, *outPath); err != nil {
+		log.Fatalf("保存图片失败: %v", err)
+	}
+
+	fmt.Printf("✅ 绘图成功! 已保存至: %s\n", *outPath)
+}
+
+// curvePoint 是权益/回撤曲线上的一个点，X 轴的语义取决于 --x-axis
+type curvePoint struct {
+	x float64
+	y float64
+}
+
+// buildCurves 一次遍历同时算出权益曲线和"相对历史最高点"的回撤曲线
+func buildCurves(txs []model.Transaction, xAxisMode string) (equity plotter.XYs, drawdown plotter.XYs) {
+	equity = make(plotter.XYs, len(txs))
+	drawdown = make(plotter.XYs, len(txs))
+
+	var balance, peak float64
+	for i, tx := range txs {
+		balance += tx.Amount
+		if balance > peak {
+			peak = balance
+		}
+
+		x := xValue(i, tx, xAxisMode)
+		equity[i].X = x
+		equity[i].Y = balance
+
+		// 回撤曲线画成负值面积图，0 以上不填充
+		drawdown[i].X = x
+		drawdown[i].Y = -(peak - balance)
+	}
+	return equity, drawdown
+}
+
+func xValue(index int, tx model.Transaction, xAxisMode string) float64 {
+	if xAxisMode == "time" {
+		return float64(tx.Timestamp.Unix())
+	}
+	return float64(index + 1)
+}
+
+// newEquityPlot 画权益曲线，叠加目标线和 [InitialCapital, Target] 的色带
+func newEquityPlot(pts plotter.XYs, status model.PortfolioStatus, xAxisMode string) *plot.Plot {
+	p := plot.New()
+	p.Title.Text = "Project 911: Equity Curve"
+	p.Y.Label.Text = "Equity (USD)"
+	applyXAxis(p, xAxisMode)
+
+	p.Add(plotter.NewGrid())
+
+	// "本金-目标"色带：本金以下是风险区，本金到目标之间是通往 911 的利润区
+	if band, err := newCapitalAtRiskBand(pts, status.InitialCapital, status.Target); err == nil {
+		p.Add(band)
+	}
+
+	line, points, err := plotter.NewLinePoints(pts)
+	if err != nil {
+		log.Fatalf("创建曲线失败: %v", err)
+	}
+	line.Color = color.RGBA{R: 0, G: 128, B: 255, A: 255} // 科技蓝
+	line.Width = vg.Points(2)
+	points.Shape = nil // 不显示具体的点，只显示线，保持整洁
+
+	targetLine, _ := plotter.NewLine(plotter.XYs{
+		{X: pts[0].X, Y: status.Target},
+		{X: pts[len(pts)-1].X, Y: status.Target},
+	})
+	targetLine.Color = color.RGBA{R: 255, G: 0, B: 0, A: 100} // 红色虚线效果
+	targetLine.LineStyle.Dashes = []vg.Length{vg.Points(5), vg.Points(5)}
+
+	p.Add(line, targetLine)
+	return p
+}
+
+// newCapitalAtRiskBand 画一条在 [initialCapital, target] 之间的水平色带，
+// 用两条填充曲线的差集实现 (gonum/plot 没有现成的 hband 图元)
+func newCapitalAtRiskBand(pts plotter.XYs, initialCapital, target float64) (*plotter.Polygon, error) {
+	if len(pts) == 0 {
+		return nil, fmt.Errorf("空数据，无法画色带")
+	}
+
+	minX, maxX := pts[0].X, pts[0].X
+	for _, pt := range pts {
+		if pt.X < minX {
+			minX = pt.X
+		}
+		if pt.X > maxX {
+			maxX = pt.X
+		}
+	}
+
+	poly, err := plotter.NewPolygon(plotter.XYs{
+		{X: minX, Y: initialCapital},
+		{X: maxX, Y: initialCapital},
+		{X: maxX, Y: target},
+		{X: minX, Y: target},
+	})
+	if err != nil {
+		return nil, err
+	}
+	poly.Color = color.RGBA{R: 0, G: 200, B: 0, A: 30} // 淡绿色："利润朝 911 迈进"区
+	poly.LineStyle.Width = 0
+	return poly, nil
+}
+
+// newDrawdownPlot 画回撤曲线，回撤区间 (0 以下) 填充为红色
+func newDrawdownPlot(pts plotter.XYs, xAxisMode string) *plot.Plot {
+	p := plot.New()
+	p.Title.Text = "Drawdown from Peak"
+	p.Y.Label.Text = "Drawdown (USD)"
+	applyXAxis(p, xAxisMode)
+	p.Add(plotter.NewGrid())
+
+	area, err := plotter.NewLine(pts)
+	if err != nil {
+		log.Fatalf("创建回撤曲线失败: %v", err)
+	}
+	area.Color = color.RGBA{R: 220, G: 0, B: 0, A: 255}
+	area.FillColor = color.RGBA{R: 220, G: 0, B: 0, A: 80} // 负值区域填充红色
+
+	p.Add(area)
+	return p
+}
+
+// newRollingPnLPlot 画按 N 笔一组滑动累加的 PnL 柱状图
+func newRollingPnLPlot(txs []model.Transaction, windowSize int) *plot.Plot {
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("Rolling %d-Trade PnL", windowSize)
+	p.X.Label.Text = "Trade Count"
+	p.Y.Label.Text = "PnL (USD)"
+	p.Add(plotter.NewGrid())
+
+	var pnlSeries []float64
+	for _, tx := range txs {
+		if tx.Type == model.TypePnL {
+			pnlSeries = append(pnlSeries, tx.Amount)
+		}
+	}
+
+	values := make(plotter.Values, len(pnlSeries))
+	for i := range pnlSeries {
+		start := i - windowSize + 1
+		if start < 0 {
+			start = 0
+		}
+		var sum float64
+		for _, v := range pnlSeries[start : i+1] {
+			sum += v
+		}
+		values[i] = sum
+	}
+
+	bars, err := plotter.NewBarChart(values, vg.Points(3))
+	if err != nil {
+		log.Fatalf("创建滚动盈亏柱状图失败: %v", err)
+	}
+	bars.Color = color.RGBA{R: 0, G: 128, B: 255, A: 180}
+	p.Add(bars)
+	return p
+}
+
+// applyXAxis 根据模式设置 X 轴标签和刻度格式
+func applyXAxis(p *plot.Plot, xAxisMode string) {
+	if xAxisMode == "time" {
+		p.X.Label.Text = "Time"
+		p.X.Tick.Marker = plot.TimeTicks{Format: "2006-01-02"}
+		return
+	}
+	p.X.Label.Text = "Trade Count"
+}
+
+// saveStacked 把多个子图按行纵向堆叠，渲染成一张 PNG
+func saveStacked(plots [][]*plot.Plot, width vg.Length, outPath string) error {
+	rows := len(plots)
+	heightPerRow := 3 * vg.Inch
+	img := vgimg.New(width, heightPerRow*vg.Length(rows))
+	dc := draw.New(img)
+
+	tiles := draw.Tiles{Rows: rows, Cols: 1}
+	canvases := plot.Align(plots, tiles, dc)
+
+	for j := range plots {
+		for i := range plots[j] {
+			if plots[j][i] != nil {
+				plots[j][i].Draw(canvases[j][i])
+			}
+		}
+	}
+
+	w, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	png := vgimg.PngCanvas{Canvas: img}
+	_, err = png.WriteTo(w)
+	return err
+}
@@ -0,0 +1,230 @@
+package analytics
+
+import (
+	"911/internal/model"
+	"math"
+	"sort"
+	"time"
+)
+
+// daysPerYear 用 365 而不是 252，因为加密货币一周七天都在交易
+const daysPerYear = 365.0
+
+// RiskReport 是对整条权益曲线算出来的一组风险/回报指标
+type RiskReport struct {
+	MaxDrawdownValue     float64       // 最大回撤的绝对金额 (峰值 - 谷值)
+	MaxDrawdownPct       float64       // 最大回撤百分比
+	LongestDrawdownDays  int           // 最长连续回撤天数 (权益低于前高的天数)
+	AnnualizedVolatility float64       // 年化波动率 (基于日收益率标准差)
+	Sharpe               float64       // 夏普比率
+	Sortino              float64       // 索提诺比率 (只惩罚下行波动)
+	CAGR                 float64       // 年化复合增长率
+	Duration             time.Duration // 统计区间跨度 (从首笔入金到现在)
+}
+
+// ComputeRiskReport 从流水构建逐日权益曲线，并计算风险指标
+// riskFreeRate 是年化无风险利率 (如 0.0 表示不考虑无风险收益)
+func ComputeRiskReport(txs []model.Transaction, riskFreeRate float64, now time.Time) RiskReport {
+	equitySeries := buildDailyEquitySeries(txs, now)
+	if len(equitySeries) < 2 {
+		return RiskReport{}
+	}
+
+	returns := dailyLogReturns(equitySeries)
+
+	ddValue, ddPct, ddDays := maxDrawdown(equitySeries)
+	vol := annualizedVolatility(returns)
+	sharpe := sharpeRatio(returns, riskFreeRate)
+	sortino := sortinoRatio(returns, riskFreeRate)
+	cagr := compoundAnnualGrowthRate(equitySeries[0].balance, equitySeries[len(equitySeries)-1].balance, len(equitySeries)-1)
+
+	return RiskReport{
+		MaxDrawdownValue:     ddValue,
+		MaxDrawdownPct:       ddPct,
+		LongestDrawdownDays:  ddDays,
+		AnnualizedVolatility: vol,
+		Sharpe:               sharpe,
+		Sortino:              sortino,
+		CAGR:                 cagr,
+		Duration:             equitySeries[len(equitySeries)-1].day.Sub(equitySeries[0].day),
+	}
+}
+
+// dailyPoint 是权益曲线上前向填充后的一天
+type dailyPoint struct {
+	day     time.Time
+	balance float64
+}
+
+// buildDailyEquitySeries 把离散的交易记录重采样为逐日的权益曲线，
+// 两笔交易之间的空白日用前一天的余额前向填充 (与 cmd/chart 的累加口径一致)
+func buildDailyEquitySeries(txs []model.Transaction, now time.Time) []dailyPoint {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	sorted := make([]model.Transaction, len(txs))
+	copy(sorted, txs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	startDay := truncateToDay(sorted[0].Timestamp)
+	endDay := truncateToDay(now)
+	if endDay.Before(startDay) {
+		endDay = startDay
+	}
+
+	// balance-by-day: 先把每天内的交易累加到对应的天
+	balanceByDay := make(map[int64]float64)
+	var runningBalance float64
+	txIdx := 0
+	for d := startDay; !d.After(endDay); d = d.AddDate(0, 0, 1) {
+		for txIdx < len(sorted) && !truncateToDay(sorted[txIdx].Timestamp).After(d) {
+			runningBalance += sorted[txIdx].Amount
+			txIdx++
+		}
+		balanceByDay[d.Unix()] = runningBalance
+	}
+
+	series := make([]dailyPoint, 0, len(balanceByDay))
+	for d := startDay; !d.After(endDay); d = d.AddDate(0, 0, 1) {
+		series = append(series, dailyPoint{day: d, balance: balanceByDay[d.Unix()]})
+	}
+	return series
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// dailyLogReturns 计算逐日对数收益率，跳过权益为零或负的异常日 (早期可能本金未到账)
+func dailyLogReturns(series []dailyPoint) []float64 {
+	var returns []float64
+	for i := 1; i < len(series); i++ {
+		prev, cur := series[i-1].balance, series[i].balance
+		if prev <= 0 || cur <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(cur/prev))
+	}
+	return returns
+}
+
+// maxDrawdown 扫描权益曲线，返回最大回撤金额、百分比，以及最长的连续回撤天数
+func maxDrawdown(series []dailyPoint) (value float64, pct float64, longestDays int) {
+	peak := series[0].balance
+	peakDay := series[0].day
+	var maxValue, maxPct float64
+	var longest int
+
+	for _, p := range series {
+		if p.balance > peak {
+			peak = p.balance
+			peakDay = p.day
+		}
+		drawdown := peak - p.balance
+		if drawdown > maxValue {
+			maxValue = drawdown
+			if peak > 0 {
+				maxPct = drawdown / peak * 100
+			}
+		}
+		if p.balance < peak {
+			underwaterDays := int(p.day.Sub(peakDay).Hours() / 24)
+			if underwaterDays > longest {
+				longest = underwaterDays
+			}
+		}
+	}
+
+	return maxValue, maxPct, longest
+}
+
+// annualizedVolatility 年化波动率 = 日收益率标准差 * sqrt(365)
+func annualizedVolatility(returns []float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	return stdDev(returns) * math.Sqrt(daysPerYear)
+}
+
+// sharpeRatio 夏普比率，rf 按年化输入，内部换算成日度基准
+func sharpeRatio(returns []float64, annualRiskFreeRate float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	dailyRf := annualRiskFreeRate / daysPerYear
+	excess := make([]float64, len(returns))
+	for i, r := range returns {
+		excess[i] = r - dailyRf
+	}
+	sd := stdDev(returns)
+	if sd == 0 {
+		return 0
+	}
+	return mean(excess) / sd * math.Sqrt(daysPerYear)
+}
+
+// sortinoRatio 索提诺比率，只用负收益 (相对日度无风险基准) 计算下行标准差
+func sortinoRatio(returns []float64, annualRiskFreeRate float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	dailyRf := annualRiskFreeRate / daysPerYear
+
+	var sumSquaredDownside float64
+	var downsideCount int
+	var sumExcess float64
+	for _, r := range returns {
+		excess := r - dailyRf
+		sumExcess += excess
+		if excess < 0 {
+			sumSquaredDownside += excess * excess
+			downsideCount++
+		}
+	}
+
+	if downsideCount == 0 {
+		return 0
+	}
+	downsideDeviation := math.Sqrt(sumSquaredDownside / float64(downsideCount))
+	if downsideDeviation == 0 {
+		return 0
+	}
+	meanExcess := sumExcess / float64(len(returns))
+	return meanExcess / downsideDeviation * math.Sqrt(daysPerYear)
+}
+
+// compoundAnnualGrowthRate 从首笔余额到末笔余额的年化复合增长率
+func compoundAnnualGrowthRate(start, end float64, days int) float64 {
+	if start <= 0 || end <= 0 || days <= 0 {
+		return 0
+	}
+	years := float64(days) / daysPerYear
+	return math.Pow(end/start, 1/years) - 1
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func stdDev(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	m := mean(xs)
+	var sumSq float64
+	for _, x := range xs {
+		diff := x - m
+		sumSq += diff * diff
+	}
+	return math.Sqrt(sumSq / float64(len(xs)-1))
+}
@@ -0,0 +1,247 @@
+package binance
+
+import (
+	"911/internal/exchange"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// BaseURL 指向 Binance USD-M 合约接口，现货部分的出入金/成交走同一套签名方式
+const BaseURL = "https://fapi.binance.com"
+
+// pageLimit 是单页最大条数，income/userTrades 接口都封顶在这个值
+const pageLimit = 1000
+
+// incomeMaxWindow 是 /fapi/v1/income 单次查询允许的最大 startTime~endTime 跨度
+const incomeMaxWindow = 200 * 24 * time.Hour
+
+// tradesMaxWindow 是 /fapi/v1/userTrades 单次查询允许的最大 startTime~endTime 跨度
+const tradesMaxWindow = 7 * 24 * time.Hour
+
+func init() {
+	exchange.Register("binance", func(cfg exchange.Config) (exchange.Exchange, error) {
+		return NewClient(cfg), nil
+	})
+}
+
+// Income 对应 /fapi/v1/income 返回的一条资金流水 (资金费/已实现盈亏/出入金)
+type Income struct {
+	Symbol     string `json:"symbol"`
+	IncomeType string `json:"incomeType"` // REALIZED_PNL / FUNDING_FEE / TRANSFER 等
+	Income     string `json:"income"`
+	Asset      string `json:"asset"`
+	Time       int64  `json:"time"`
+	TranID     int64  `json:"tranId"`
+}
+
+// Trade 对应 /fapi/v1/userTrades 返回的一条成交记录
+type Trade struct {
+	Symbol      string `json:"symbol"`
+	OrderID     int64  `json:"orderId"`
+	Time        int64  `json:"time"`
+	RealizedPnl string `json:"realizedPnl"`
+	Commission  string `json:"commission"`
+}
+
+type Client struct {
+	Config exchange.Config
+	Client *http.Client
+}
+
+func NewClient(cfg exchange.Config) *Client {
+	return &Client{
+		Config: cfg,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 实现 exchange.Exchange
+func (c *Client) Name() string {
+	return "binance"
+}
+
+// FetchBills 拉取合约的资金流水 (income history) 与成交费 (trade fills)，
+// 合并转换为统一的 RawBill
+func (c *Client) FetchBills(ctx context.Context, since time.Time) ([]exchange.RawBill, error) {
+	incomes, err := c.fetchIncomeHistory(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("拉取 Binance 资金流水失败: %v", err)
+	}
+
+	trades, err := c.fetchUserTrades(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("拉取 Binance 成交记录失败: %v", err)
+	}
+
+	var raw []exchange.RawBill
+	for _, inc := range incomes {
+		billType := normalizeIncomeType(inc.IncomeType, inc.Income)
+
+		// 只有真正的成交才需要 OrdId 走聚合路径；TRANSFER/FUNDING_FEE 的 tranId 对
+		// aggregateAndMapBills 没有意义，留空让它们走 determineType 的独立记账路径
+		var ordId string
+		if billType == exchange.BillTypeTrade {
+			ordId = strconv.FormatInt(inc.TranID, 10)
+		}
+
+		raw = append(raw, exchange.RawBill{
+			Ts:       strconv.FormatInt(inc.Time, 10),
+			Type:     billType,
+			BalChg:   inc.Income,
+			Ccy:      inc.Asset,
+			InstId:   inc.Symbol,
+			OrdId:    ordId,
+			Exchange: "binance",
+		})
+	}
+
+	for _, t := range trades {
+		if t.RealizedPnl == "0" && t.Commission == "0" {
+			continue
+		}
+		raw = append(raw, exchange.RawBill{
+			Ts:       strconv.FormatInt(t.Time, 10),
+			Type:     exchange.BillTypeTrade,
+			BalChg:   t.RealizedPnl,
+			InstId:   t.Symbol,
+			OrdId:    strconv.FormatInt(t.OrderID, 10),
+			Exchange: "binance",
+		})
+	}
+
+	return raw, nil
+}
+
+// fetchIncomeHistory 按 incomeMaxWindow 分窗口、按 pageLimit 翻页拉取全部资金流水，
+// 避免 Binance 的时间窗口上限和单页条数上限悄悄丢掉历史数据
+func (c *Client) fetchIncomeHistory(ctx context.Context, since time.Time) ([]Income, error) {
+	var all []Income
+	cursor := since
+	now := time.Now()
+
+	for cursor.Before(now) {
+		windowEnd := cursor.Add(incomeMaxWindow)
+		if windowEnd.After(now) {
+			windowEnd = now
+		}
+
+		params := url.Values{}
+		params.Set("startTime", strconv.FormatInt(cursor.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(windowEnd.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(pageLimit))
+
+		var page []Income
+		if err := c.signedGet(ctx, "/fapi/v1/income", params, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+
+		if len(page) < pageLimit {
+			// 本窗口已取完，推进到下一个窗口
+			cursor = windowEnd.Add(time.Millisecond)
+		} else {
+			// 命中单页上限，从最后一条记录之后继续翻页，而不是跳到窗口末尾
+			cursor = time.UnixMilli(page[len(page)-1].Time + 1)
+		}
+	}
+
+	return all, nil
+}
+
+// fetchUserTrades 按 tradesMaxWindow 分窗口、按 pageLimit 翻页拉取全部成交记录
+func (c *Client) fetchUserTrades(ctx context.Context, since time.Time) ([]Trade, error) {
+	var all []Trade
+	cursor := since
+	now := time.Now()
+
+	for cursor.Before(now) {
+		windowEnd := cursor.Add(tradesMaxWindow)
+		if windowEnd.After(now) {
+			windowEnd = now
+		}
+
+		params := url.Values{}
+		params.Set("startTime", strconv.FormatInt(cursor.UnixMilli(), 10))
+		params.Set("endTime", strconv.FormatInt(windowEnd.UnixMilli(), 10))
+		params.Set("limit", strconv.Itoa(pageLimit))
+
+		var page []Trade
+		if err := c.signedGet(ctx, "/fapi/v1/userTrades", params, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+
+		if len(page) < pageLimit {
+			cursor = windowEnd.Add(time.Millisecond)
+		} else {
+			cursor = time.UnixMilli(page[len(page)-1].Time + 1)
+		}
+	}
+
+	return all, nil
+}
+
+// signedGet 发起一个带 HMAC-SHA256 签名的 GET 请求
+func (c *Client) signedGet(ctx context.Context, path string, params url.Values, out interface{}) error {
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	query := params.Encode()
+	params.Set("signature", computeHmacSha256(query, c.Config.SecretKey))
+
+	fullURL := BaseURL + path + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-MBX-APIKEY", c.Config.ApiKey)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Binance API HTTP Error: %s", string(body))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// normalizeIncomeType 把 Binance income 的字符串类型映射为归一化类型
+// Binance 的出入金都归在 "TRANSFER" 下，只能用金额正负号区分方向
+func normalizeIncomeType(incomeType, income string) string {
+	switch incomeType {
+	case "TRANSFER":
+		amt, _ := strconv.ParseFloat(income, 64)
+		if amt < 0 {
+			return exchange.BillTypeWithdrawal
+		}
+		return exchange.BillTypeDeposit
+	case "FUNDING_FEE":
+		return exchange.BillTypeFundingFee
+	default:
+		return exchange.BillTypeTrade
+	}
+}
+
+// computeHmacSha256 计算签名
+func computeHmacSha256(message string, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RawBill 是各交易所流水记录的统一中间格式
+// 字段沿用 OKX Bill 的命名习惯，Binance 等交易所在各自的实现里做映射
+type RawBill struct {
+	Ts       string // 毫秒时间戳字符串
+	Type     string // 归一化类型，取值见下方 BillType* 常量
+	SubType  string // 交易所原始子类型码 (如 OKX 的 open-long/close-short)，非聚合字段仅供 side 推断参考
+	Pnl      string
+	BalChg   string // 余额变动
+	Ccy      string
+	InstId   string
+	OrdId    string // 核心聚合字段
+	Exchange string // 来源交易所，由具体实现自动填充
+}
+
+// 归一化的流水类型，所有交易所实现都应将自己的原始类型码映射到这几类
+// 这样 aggregateAndMapBills 才能在不认识具体交易所的情况下正确分类
+const (
+	BillTypeDeposit    = "deposit"
+	BillTypeWithdrawal = "withdrawal"
+	BillTypeFundingFee = "funding_fee"
+	BillTypeTrade      = "trade" // 开平仓、成交费等与订单强相关的腿，按 OrdId 聚合
+)
+
+// Exchange 是接入一个交易所数据源所需实现的最小接口
+type Exchange interface {
+	// Name 返回交易所标识，如 "okx"、"binance"
+	Name() string
+	// FetchBills 拉取 since 之后的账单流水
+	FetchBills(ctx context.Context, since time.Time) ([]RawBill, error)
+}
+
+// Config 是 config.json 中每一项交易所配置的通用结构
+// 各实现按需读取其中的字段，多余字段忽略
+type Config struct {
+	Type       string `json:"type"`
+	ApiKey     string `json:"api_key"`
+	SecretKey  string `json:"secret_key"`
+	Passphrase string `json:"passphrase"` // OKX 专用
+	Simulated  bool   `json:"is_simulated"`
+}
+
+// Factory 根据 Config 构造一个 Exchange 实例
+type Factory func(cfg Config) (Exchange, error)
+
+var registry = make(map[string]Factory)
+
+// Register 注册一个新的交易所类型，供 config.json 中的 "type" 字段引用
+// 各交易所实现包应在 init() 中调用本函数完成自注册
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// New 按 cfg.Type 查表构造对应的 Exchange 实例
+func New(cfg Config) (Exchange, error) {
+	factory, ok := registry[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("未知的交易所类型: %q", cfg.Type)
+	}
+	return factory(cfg)
+}
@@ -1,6 +1,8 @@
 package okx
 
 import (
+	"911/internal/exchange"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -8,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,11 +19,10 @@ const (
 	BaseURL = "https://www.okx.com"
 )
 
-type Config struct {
-	ApiKey     string `json:"api_key"`
-	SecretKey  string `json:"secret_key"`
-	Passphrase string `json:"passphrase"`
-	Simulated  bool   `json:"is_simulated"`
+func init() {
+	exchange.Register("okx", func(cfg exchange.Config) (exchange.Exchange, error) {
+		return NewClient(cfg), nil
+	})
 }
 
 type BillResponse struct {
@@ -44,20 +46,68 @@ type Bill struct {
 }
 
 type Client struct {
-	Config Config
+	Config exchange.Config
 	Client *http.Client
 }
 
-func NewClient(cfg Config) *Client {
+func NewClient(cfg exchange.Config) *Client {
 	return &Client{
 		Config: cfg,
 		Client: &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
-// FetchBills 自动分页获取归档数据 (3个月)
+// Name 实现 exchange.Exchange
+func (c *Client) Name() string {
+	return "okx"
+}
+
+// FetchBills 实现 exchange.Exchange，内部调用归档接口后按 since 过滤并转换为 RawBill
+func (c *Client) FetchBills(ctx context.Context, since time.Time) ([]exchange.RawBill, error) {
+	bills, err := c.fetchArchiveBills(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []exchange.RawBill
+	for _, b := range bills {
+		tsInt, _ := strconv.ParseInt(b.Ts, 10, 64)
+		if time.UnixMilli(tsInt).Before(since) {
+			continue
+		}
+		raw = append(raw, exchange.RawBill{
+			Ts:       b.Ts,
+			Type:     normalizeType(b.Type),
+			SubType:  b.SubType,
+			Pnl:      b.Pnl,
+			BalChg:   b.BalChg,
+			Ccy:      b.Ccy,
+			InstId:   b.InstId,
+			OrdId:    b.OrdId,
+			Exchange: "okx",
+		})
+	}
+	return raw, nil
+}
+
+// normalizeType 把 OKX 账单的数字类型码映射为跨交易所统一的归一化类型
+// 参考: https://www.okx.com/docs-v5/en/#trading-account-rest-api-get-bills-details-last-3-months
+func normalizeType(billType string) string {
+	switch billType {
+	case "1":
+		return exchange.BillTypeDeposit
+	case "2":
+		return exchange.BillTypeWithdrawal
+	case "8":
+		return exchange.BillTypeFundingFee
+	default:
+		return exchange.BillTypeTrade
+	}
+}
+
+// fetchArchiveBills 自动分页获取归档数据 (3个月)
 // 包含智能限流重试机制
-func (c *Client) FetchBills() ([]Bill, error) {
+func (c *Client) fetchArchiveBills(ctx context.Context) ([]Bill, error) {
 	requestPath := "/api/v5/account/bills-archive"
 
 	var allBills []Bill
@@ -73,8 +123,8 @@ func (c *Client) FetchBills() ([]Bill, error) {
 		}
 
 		fullURL := BaseURL + requestPath + params
-		
-		req, err := http.NewRequest("GET", fullURL, nil)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -98,7 +148,7 @@ func (c *Client) FetchBills() ([]Bill, error) {
 		if err != nil {
 			return nil, err
 		}
-		
+
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 
@@ -118,7 +168,7 @@ func (c *Client) FetchBills() ([]Bill, error) {
 		if err := json.Unmarshal(body, &result); err != nil {
 			return nil, err
 		}
-		
+
 		// 再次检查业务层面的错误码
 		if result.Code != "0" {
 			if result.Code == "50011" {
@@ -133,7 +183,7 @@ func (c *Client) FetchBills() ([]Bill, error) {
 		if len(result.Data) > 0 {
 			allBills = append(allBills, result.Data...)
 			fmt.Printf("   -> 第 %d 页获取成功 (本页 %d 条)...\n", pageCount, len(result.Data))
-			
+
 			// 更新游标
 			afterCursor = result.Data[len(result.Data)-1].BillID
 			pageCount++
@@ -144,9 +194,9 @@ func (c *Client) FetchBills() ([]Bill, error) {
 		if len(result.Data) < 100 {
 			break
 		}
-		
+
 		// 每次成功后稍微休息一下，降低触发限流概率
-		time.Sleep(1 * time.Second) 
+		time.Sleep(1 * time.Second)
 	}
 
 	return allBills, nil
@@ -157,4 +207,4 @@ func computeHmacSha256(message string, secret string) string {
 	h := hmac.New(sha256.New, []byte(secret))
 	h.Write([]byte(message))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
-}
\ No newline at end of file
+}
@@ -1,51 +1,156 @@
-package model
-
-import "time"
-
-// TransactionType 定义交易类型枚举
-type TransactionType string
-
-const (
-	TypeDeposit    TransactionType = "DEPOSIT"    // 入金
-	TypeWithdrawal TransactionType = "WITHDRAWAL" // 出金 (Harvest)
-	TypePnL        TransactionType = "PNL"        // 交易盈亏
-)
-
-// Transaction 对应 ledger.csv 中的一行
-type Transaction struct {
-	Timestamp time.Time       `json:"timestamp"`
-	Type      TransactionType `json:"type"`
-	Amount    float64         `json:"amount"` // 金额 (出金通常为负数)
-	Asset     string          `json:"asset"`
-	Note      string          `json:"note"`
-}
-
-// PortfolioStatus 账户当前的健康状态
-type PortfolioStatus struct {
-	InitialCapital float64 // 初始本金 (Seed)
-	CurrentBalance float64 // 当前余额 (Asset Value)
-	TotalPnL       float64 // 累计交易盈亏
-	TotalHarvested float64 // 累计出金 (Realized Life)
-	
-	WinCount       int     // 盈利次数
-	LossCount      int     // 亏损次数
-	
-	Target         float64 // 目标金额 (Porsche 911 Price)
-}
-
-// Progress 计算离保时捷的进度百分比
-func (p PortfolioStatus) Progress() float64 {
-	if p.Target == 0 {
-		return 0
-	}
-	return (p.CurrentBalance / p.Target) * 100
-}
-
-// WinRate 计算胜率
-func (p PortfolioStatus) WinRate() float64 {
-	totalTrades := p.WinCount + p.LossCount
-	if totalTrades == 0 {
-		return 0
-	}
-	return float64(p.WinCount) / float64(totalTrades) * 100
-}
\ No newline at end of file
+package model
+
+import "time"
+
+// TransactionType 定义交易类型枚举
+type TransactionType string
+
+const (
+	TypeDeposit    TransactionType = "DEPOSIT"    // 入金
+	TypeWithdrawal TransactionType = "WITHDRAWAL" // 出金 (Harvest)
+	TypePnL        TransactionType = "PNL"        // 交易盈亏
+)
+
+// PositionSide 定义持仓方向
+type PositionSide string
+
+const (
+	SideLong  PositionSide = "LONG"
+	SideShort PositionSide = "SHORT"
+	SideFlat  PositionSide = "FLAT" // 无法判断方向的记录 (如资金费、出入金)
+)
+
+// Transaction 对应账本里的一行
+type Transaction struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Type      TransactionType `json:"type"`
+	Amount    float64         `json:"amount"` // 金额 (出金通常为负数)
+	Asset     string          `json:"asset"`
+	InstId    string          `json:"inst_id"` // 合约/交易对，如 BTC-USDT-SWAP
+	OrdId     string          `json:"ord_id"`  // 来源交易所的订单号，配合 timestamp+amount 做幂等去重
+	Side      PositionSide    `json:"side"`    // 持仓方向，仅 PNL 记录有意义
+	Note      string          `json:"note"`
+}
+
+// PortfolioStatus 账户当前的健康状态
+type PortfolioStatus struct {
+	InitialCapital float64 // 初始本金 (Seed)
+	CurrentBalance float64 // 当前余额 (Asset Value)
+	TotalPnL       float64 // 累计交易盈亏
+	TotalHarvested float64 // 累计出金 (Realized Life)
+
+	WinCount  int // 盈利次数
+	LossCount int // 亏损次数
+
+	Target float64 // 目标金额 (Porsche 911 Price)
+
+	ByInstrument map[string]*InstrumentStats // 按 InstId 分组的统计
+}
+
+// InstrumentStats 单个合约/交易对维度的统计数据
+// 所有的均值/胜率类指标都按 WinCount/LossCount/SumWin/SumLoss 这种累加值现算，
+// 和 PortfolioStatus 的 WinRate() 保持同样的风格
+type InstrumentStats struct {
+	InstId string
+
+	WinCount  int
+	LossCount int
+	SumWin    float64 // 盈利交易的总盈利 (正数)
+	SumLoss   float64 // 亏损交易的总亏损 (正数，已取绝对值)
+
+	LongWinCount  int
+	LongLossCount int
+	LongSumWin    float64
+	LongSumLoss   float64
+
+	ShortWinCount  int
+	ShortLossCount int
+	ShortSumWin    float64
+	ShortSumLoss   float64
+}
+
+// TotalTrades 该合约的总交易笔数
+func (s *InstrumentStats) TotalTrades() int {
+	return s.WinCount + s.LossCount
+}
+
+// WinRate 该合约的胜率 (百分比)
+func (s *InstrumentStats) WinRate() float64 {
+	total := s.TotalTrades()
+	if total == 0 {
+		return 0
+	}
+	return float64(s.WinCount) / float64(total) * 100
+}
+
+// AvgWin 平均每笔盈利
+func (s *InstrumentStats) AvgWin() float64 {
+	if s.WinCount == 0 {
+		return 0
+	}
+	return s.SumWin / float64(s.WinCount)
+}
+
+// AvgLoss 平均每笔亏损 (正数)
+func (s *InstrumentStats) AvgLoss() float64 {
+	if s.LossCount == 0 {
+		return 0
+	}
+	return s.SumLoss / float64(s.LossCount)
+}
+
+// ProfitFactor 盈亏比 = 总盈利 / 总亏损
+func (s *InstrumentStats) ProfitFactor() float64 {
+	if s.SumLoss == 0 {
+		if s.SumWin == 0 {
+			return 0
+		}
+		return s.SumWin // 没有亏损时直接用总盈利表示 (近似 +Inf 但避免除零)
+	}
+	return s.SumWin / s.SumLoss
+}
+
+// LongExpectancy 多头期望值 = 胜率*平均盈利 - 败率*平均亏损
+func (s *InstrumentStats) LongExpectancy() float64 {
+	return expectancy(s.LongWinCount, s.LongLossCount, s.LongSumWin, s.LongSumLoss)
+}
+
+// ShortExpectancy 空头期望值 = 胜率*平均盈利 - 败率*平均亏损
+func (s *InstrumentStats) ShortExpectancy() float64 {
+	return expectancy(s.ShortWinCount, s.ShortLossCount, s.ShortSumWin, s.ShortSumLoss)
+}
+
+func expectancy(winCount, lossCount int, sumWin, sumLoss float64) float64 {
+	total := winCount + lossCount
+	if total == 0 {
+		return 0
+	}
+	avgWin := 0.0
+	if winCount > 0 {
+		avgWin = sumWin / float64(winCount)
+	}
+	avgLoss := 0.0
+	if lossCount > 0 {
+		avgLoss = sumLoss / float64(lossCount)
+	}
+	winRate := float64(winCount) / float64(total)
+	lossRate := float64(lossCount) / float64(total)
+	return winRate*avgWin - lossRate*avgLoss
+}
+
+// Progress 计算离保时捷的进度百分比
+func (p PortfolioStatus) Progress() float64 {
+	if p.Target == 0 {
+		return 0
+	}
+	return (p.CurrentBalance / p.Target) * 100
+}
+
+// WinRate 计算胜率
+func (p PortfolioStatus) WinRate() float64 {
+	totalTrades := p.WinCount + p.LossCount
+	if totalTrades == 0 {
+		return 0
+	}
+	return float64(p.WinCount) / float64(totalTrades) * 100
+}
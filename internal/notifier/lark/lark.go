@@ -0,0 +1,80 @@
+package lark
+
+import (
+	"911/internal/notifier"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func init() {
+	notifier.Register("lark", func(cfg notifier.Config) (notifier.Notifier, error) {
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("lark notifier 缺少 webhook_url")
+		}
+		return NewClient(cfg.WebhookURL), nil
+	})
+}
+
+// textMessage 是飞书自定义机器人的文本消息 payload
+// 参考: https://open.feishu.cn/document/client-docs/bot-v3/add-custom-bot
+type textMessage struct {
+	MsgType string      `json:"msg_type"`
+	Content textContent `json:"content"`
+}
+
+type textContent struct {
+	Text string `json:"text"`
+}
+
+type Client struct {
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+func NewClient(webhookURL string) *Client {
+	return &Client{
+		WebhookURL: webhookURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 实现 notifier.Notifier
+func (c *Client) Name() string {
+	return "lark"
+}
+
+// Send 实现 notifier.Notifier，把事件渲染成一条文本消息推送到飞书群
+func (c *Client) Send(ctx context.Context, event notifier.Event) error {
+	msg := textMessage{
+		MsgType: "text",
+		Content: textContent{
+			Text: fmt.Sprintf("[%s] %s\n%s", event.Type, event.Title, event.Message),
+		},
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("飞书 Webhook 返回非 200 状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventType 定义通知事件的类型
+type EventType string
+
+const (
+	EventProgressCrossed EventType = "PROGRESS_CROSSED" // 每跨过一个 10% 的里程碑
+	EventNewHarvest      EventType = "NEW_HARVEST"      // 发生一笔出金
+	EventDrawdownAlert   EventType = "DRAWDOWN_ALERT"   // 回撤超过阈值
+	EventDailyPnLDigest  EventType = "DAILY_PNL_DIGEST" // 每日盈亏摘要
+)
+
+// Event 是推送给各个 Notifier 实现的统一事件结构
+type Event struct {
+	Type      EventType
+	Title     string
+	Message   string
+	Timestamp time.Time
+}
+
+// Notifier 是接入一个推送渠道所需实现的最小接口
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+// Config 是 config.json 中 notifiers 数组里每一项的通用结构
+// 各实现按需读取其中的字段，多余字段忽略
+type Config struct {
+	Type       string `json:"type"`
+	WebhookURL string `json:"webhook_url"` // Lark/飞书机器人 Webhook
+	BotToken   string `json:"bot_token"`   // Telegram Bot Token
+	ChatID     string `json:"chat_id"`     // Telegram Chat ID
+}
+
+// Factory 根据 Config 构造一个 Notifier 实例
+type Factory func(cfg Config) (Notifier, error)
+
+var registry = make(map[string]Factory)
+
+// Register 注册一个新的通知渠道类型，供 config.json 中的 "type" 字段引用
+// 各渠道实现包应在 init() 中调用本函数完成自注册
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// New 按 cfg.Type 查表构造对应的 Notifier 实例
+func New(cfg Config) (Notifier, error) {
+	factory, ok := registry[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("未知的通知渠道类型: %q", cfg.Type)
+	}
+	return factory(cfg)
+}
+
+// SendWithRetry 对一次 Send 调用做指数退避重试，最多尝试 maxAttempts 次
+func SendWithRetry(ctx context.Context, n Notifier, event Event, maxAttempts int) error {
+	var lastErr error
+	backoff := 500 * time.Millisecond
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := n.Send(ctx, event); err != nil {
+			lastErr = err
+			if attempt == maxAttempts {
+				break
+			}
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("通知渠道 [%s] 重试 %d 次后仍然失败: %v", n.Name(), maxAttempts, lastErr)
+}
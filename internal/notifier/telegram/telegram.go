@@ -0,0 +1,68 @@
+package telegram
+
+import (
+	"911/internal/notifier"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const apiBaseURL = "https://api.telegram.org"
+
+func init() {
+	notifier.Register("telegram", func(cfg notifier.Config) (notifier.Notifier, error) {
+		if cfg.BotToken == "" || cfg.ChatID == "" {
+			return nil, fmt.Errorf("telegram notifier 缺少 bot_token 或 chat_id")
+		}
+		return NewClient(cfg.BotToken, cfg.ChatID), nil
+	})
+}
+
+type Client struct {
+	BotToken   string
+	ChatID     string
+	HTTPClient *http.Client
+}
+
+func NewClient(botToken, chatID string) *Client {
+	return &Client{
+		BotToken:   botToken,
+		ChatID:     chatID,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 实现 notifier.Notifier
+func (c *Client) Name() string {
+	return "telegram"
+}
+
+// Send 实现 notifier.Notifier，通过 Bot API 的 sendMessage 推送一条文本消息
+func (c *Client) Send(ctx context.Context, event notifier.Event) error {
+	text := fmt.Sprintf("[%s] %s\n%s", event.Type, event.Title, event.Message)
+
+	params := url.Values{}
+	params.Set("chat_id", c.ChatID)
+	params.Set("text", text)
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", apiBaseURL, c.BotToken)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Telegram API 返回非 200 状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
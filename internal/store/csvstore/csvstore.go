@@ -0,0 +1,188 @@
+package csvstore
+
+import (
+	"911/internal/model"
+	"911/internal/store"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func init() {
+	store.Register("csv", func(cfg store.Config) (store.Store, error) {
+		return New(cfg.Path), nil
+	})
+}
+
+// Store 是基于 CSV 文件的账本实现，沿用项目早期的追加写入方式
+type Store struct {
+	Path string
+}
+
+func New(path string) *Store {
+	return &Store{Path: path}
+}
+
+// All 按时间升序返回账本里的全部记录
+func (s *Store) All() ([]model.Transaction, error) {
+	if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开账本文件 [%s]: %v", s.Path, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	// 允许变长字段，兼容历史上列数较少的旧格式
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV 解析失败: %v", err)
+	}
+
+	var transactions []model.Transaction
+	for i, row := range records {
+		if i == 0 {
+			continue // Skip Header
+		}
+		tx, ok, err := parseRow(row, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			transactions = append(transactions, tx)
+		}
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Timestamp.Before(transactions[j].Timestamp)
+	})
+	return transactions, nil
+}
+
+// parseRow 把一行 CSV 解析为 Transaction，兼容三种历史列数：
+// 5 列 (timestamp,type,amount,asset,note)
+// 7 列 (+ inst_id,side)
+// 8 列 (+ ord_id)
+func parseRow(row []string, lineNo int) (model.Transaction, bool, error) {
+	if len(row) < 5 {
+		return model.Transaction{}, false, nil
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, row[0])
+	if err != nil {
+		return model.Transaction{}, false, fmt.Errorf("Line %d 时间格式错误 (需用 RFC3339): %v", lineNo, err)
+	}
+
+	amt, err := strconv.ParseFloat(row[2], 64)
+	if err != nil {
+		return model.Transaction{}, false, fmt.Errorf("Line %d 金额错误: %v", lineNo, err)
+	}
+
+	var instId, ordId, note string
+	side := model.SideFlat
+	switch {
+	case len(row) >= 8:
+		instId, ordId, side, note = row[4], row[5], model.PositionSide(row[6]), row[7]
+	case len(row) >= 7:
+		instId, side, note = row[4], model.PositionSide(row[5]), row[6]
+	default:
+		note = row[4]
+	}
+
+	return model.Transaction{
+		Timestamp: ts,
+		Type:      model.TransactionType(row[1]),
+		Amount:    amt,
+		Asset:     row[3],
+		InstId:    instId,
+		OrdId:     ordId,
+		Side:      side,
+		Note:      note,
+	}, true, nil
+}
+
+// Since 返回指定时间之后 (不含) 的记录
+func (s *Store) Since(t time.Time) ([]model.Transaction, error) {
+	all, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Transaction
+	for _, tx := range all {
+		if tx.Timestamp.After(t) {
+			result = append(result, tx)
+		}
+	}
+	return result, nil
+}
+
+// LastTimestamp 返回账本中最新一条记录的时间，账本为空时返回零值
+func (s *Store) LastTimestamp() (time.Time, error) {
+	all, err := s.All()
+	if err != nil || len(all) == 0 {
+		return time.Time{}, err
+	}
+	return all[len(all)-1].Timestamp, nil
+}
+
+// Append 幂等地追加新记录：跳过已存在的 (timestamp, ord_id, amount) 组合，
+// 修复了旧版"只认时间戳严格大于最后一条记录"规则下、同一时间戳多笔成交会被误删的 bug
+// 时间戳读写都必须用 RFC3339Nano：写盘时截断到秒会和 Dedupe 用的 UnixNano() 键对不上，
+// 同一笔毫秒精度的交易重新导入时会被误判成新记录，写出重复行
+func (s *Store) Append(txs []model.Transaction) (int, error) {
+	existing, err := s.All()
+	if err != nil {
+		return 0, err
+	}
+
+	newTxs := store.Dedupe(existing, txs)
+	if len(newTxs) == 0 {
+		return 0, nil
+	}
+
+	sort.Slice(newTxs, func(i, j int) bool {
+		return newTxs[i].Timestamp.Before(newTxs[j].Timestamp)
+	})
+
+	fileMode := os.O_APPEND | os.O_WRONLY
+	needHeader := false
+	if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+		fileMode = os.O_CREATE | os.O_WRONLY
+		needHeader = true
+	}
+
+	f, err := os.OpenFile(s.Path, fileMode, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if needHeader {
+		w.Write([]string{"timestamp", "type", "amount", "asset", "inst_id", "ord_id", "side", "note"})
+	}
+
+	for _, tx := range newTxs {
+		w.Write([]string{
+			tx.Timestamp.Format(time.RFC3339Nano),
+			string(tx.Type),
+			fmt.Sprintf("%.8f", tx.Amount),
+			tx.Asset,
+			tx.InstId,
+			tx.OrdId,
+			string(tx.Side),
+			tx.Note,
+		})
+	}
+	w.Flush()
+
+	return len(newTxs), w.Error()
+}
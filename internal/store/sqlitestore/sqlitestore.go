@@ -0,0 +1,143 @@
+package sqlitestore
+
+import (
+	"911/internal/model"
+	"911/internal/store"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // 纯 Go 实现，免 cgo
+)
+
+func init() {
+	store.Register("sqlite", func(cfg store.Config) (store.Store, error) {
+		return New(cfg.Path)
+	})
+}
+
+// timestamp 存成 UnixNano 的 INTEGER，而不是 RFC3339Nano 的 TEXT：
+// RFC3339Nano 会省略末尾的零，整秒的时间戳序列化后没有小数部分，按字节比较时
+// "." 排在 "Z" 之前，导致整秒时间戳在字典序下反而"大于"带小数的更晚时间戳，
+// ORDER BY/WHERE/MAX 在混有整秒记录时全部排序错误
+const schema = `
+CREATE TABLE IF NOT EXISTS transactions (
+	timestamp INTEGER NOT NULL,
+	type      TEXT NOT NULL,
+	amount    REAL NOT NULL,
+	asset     TEXT,
+	inst_id   TEXT,
+	ord_id    TEXT,
+	side      TEXT,
+	note      TEXT,
+	UNIQUE(timestamp, ord_id, amount)
+);
+`
+
+// Store 是基于 modernc.org/sqlite 的账本实现，用唯一索引做真正的幂等写入，
+// 解决了 CSV 版本依赖"时间戳严格递增"这条脆弱规则的问题
+type Store struct {
+	db *sql.DB
+}
+
+func New(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开 SQLite 数据库 [%s]: %v", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化表结构失败: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Append 用 INSERT OR IGNORE 依赖 (timestamp, ord_id, amount) 的唯一索引做幂等写入
+func (s *Store) Append(txs []model.Transaction) (int, error) {
+	if len(txs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO transactions
+		(timestamp, type, amount, asset, inst_id, ord_id, side, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var added int
+	for _, t := range txs {
+		res, err := stmt.Exec(t.Timestamp.UnixNano(), string(t.Type), t.Amount, t.Asset, t.InstId, t.OrdId, string(t.Side), t.Note)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		rows, _ := res.RowsAffected()
+		added += int(rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// All 按时间升序返回账本里的全部记录
+func (s *Store) All() ([]model.Transaction, error) {
+	return s.query(`SELECT timestamp, type, amount, asset, inst_id, ord_id, side, note
+		FROM transactions ORDER BY timestamp ASC`)
+}
+
+// Since 返回指定时间之后 (不含) 的记录
+func (s *Store) Since(t time.Time) ([]model.Transaction, error) {
+	return s.query(`SELECT timestamp, type, amount, asset, inst_id, ord_id, side, note
+		FROM transactions WHERE timestamp > ? ORDER BY timestamp ASC`, t.UnixNano())
+}
+
+// LastTimestamp 返回账本中最新一条记录的时间，账本为空时返回零值
+func (s *Store) LastTimestamp() (time.Time, error) {
+	var raw sql.NullInt64
+	err := s.db.QueryRow(`SELECT MAX(timestamp) FROM transactions`).Scan(&raw)
+	if err != nil || !raw.Valid {
+		return time.Time{}, err
+	}
+	return time.Unix(0, raw.Int64), nil
+}
+
+func (s *Store) query(query string, args ...interface{}) ([]model.Transaction, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		var (
+			tsRaw                                 int64
+			typ, asset, instId, ordId, side, note string
+			amount                                float64
+		)
+		if err := rows.Scan(&tsRaw, &typ, &amount, &asset, &instId, &ordId, &side, &note); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, model.Transaction{
+			Timestamp: time.Unix(0, tsRaw),
+			Type:      model.TransactionType(typ),
+			Amount:    amount,
+			Asset:     asset,
+			InstId:    instId,
+			OrdId:     ordId,
+			Side:      model.PositionSide(side),
+			Note:      note,
+		})
+	}
+	return transactions, rows.Err()
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"911/internal/model"
+	"fmt"
+	"time"
+)
+
+// Store 是账本的持久化接口，屏蔽了底层到底是 CSV 文件还是数据库
+type Store interface {
+	// Append 写入一批新记录，返回实际新增的条数 (已存在的记录会被幂等跳过)
+	Append(txs []model.Transaction) (added int, err error)
+	// All 返回账本中的全部记录，按时间升序
+	All() ([]model.Transaction, error)
+	// Since 返回指定时间之后 (不含) 的记录，按时间升序
+	Since(t time.Time) ([]model.Transaction, error)
+	// LastTimestamp 返回账本中最新一条记录的时间，账本为空时返回零值
+	LastTimestamp() (time.Time, error)
+}
+
+// Config 是 --store 相关命令行参数的通用结构
+type Config struct {
+	Backend string // "csv" 或 "sqlite"
+	Path    string // CSV 文件路径，或 SQLite 数据库文件路径
+}
+
+// Factory 根据 Config 构造一个 Store 实例
+type Factory func(cfg Config) (Store, error)
+
+var registry = make(map[string]Factory)
+
+// Register 注册一个新的存储后端，供 --store 命令行参数引用
+// 各后端实现包应在 init() 中调用本函数完成自注册
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// New 按 cfg.Backend 查表构造对应的 Store 实例
+func New(cfg Config) (Store, error) {
+	factory, ok := registry[cfg.Backend]
+	if !ok {
+		return nil, fmt.Errorf("未知的存储后端: %q", cfg.Backend)
+	}
+	return factory(cfg)
+}
+
+// dedupeKey 是幂等去重用的唯一键: (timestamp, ord_id, amount)，和各后端 Append 的去重口径保持一致
+type dedupeKey struct {
+	timestamp int64
+	ordId     string
+	amount    float64
+}
+
+func keyOf(tx model.Transaction) dedupeKey {
+	return dedupeKey{timestamp: tx.Timestamp.UnixNano(), ordId: tx.OrdId, amount: tx.Amount}
+}
+
+// Dedupe 从 candidates 中筛出 existing 里不存在的记录，调用方可以用这份结果做
+// "新增了什么" 相关的展示/通知，而不需要重新打开 Store 去反推
+func Dedupe(existing, candidates []model.Transaction) []model.Transaction {
+	seen := make(map[dedupeKey]bool, len(existing))
+	for _, tx := range existing {
+		seen[keyOf(tx)] = true
+	}
+
+	var result []model.Transaction
+	for _, tx := range candidates {
+		k := keyOf(tx)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		result = append(result, tx)
+	}
+	return result
+}